@@ -0,0 +1,195 @@
+package urlshort
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	toml "github.com/BurntSushi/toml"
+)
+
+// ParseError describes a single problem found while parsing a config
+// file, annotated with enough position information to point a user
+// straight at the offending line.
+type ParseError struct {
+	Filename string // empty when the config was parsed from raw bytes rather than a named file
+	Line     int
+	Column   int
+	Snippet  string // the offending source line, with a caret under Column
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	where := fmt.Sprintf("line %d", e.Line)
+	if e.Filename != "" {
+		where = fmt.Sprintf("%s:%d", e.Filename, e.Line)
+	}
+	if e.Snippet == "" {
+		return fmt.Sprintf("%s: %s", where, e.Err)
+	}
+	return fmt.Sprintf("%s: %s\n%s", where, e.Err, e.Snippet)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// MultiError collects every validation error found in a single config
+// file so operators can fix them all in one pass instead of one at a
+// time.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d error(s) found:\n%s", len(m), strings.Join(msgs, "\n"))
+}
+
+// validatePathURLs checks a parsed path list for semantic problems
+// that syntax validation alone can't catch: empty paths, paths that
+// aren't absolute, and duplicate paths. (Malformed URLs are already
+// rejected at decode time by RedirectURL.) It returns a MultiError
+// listing every problem found, or nil if pathURLs is valid.
+func validatePathURLs(pathURLs []PathURL) error {
+	var errs MultiError
+	seen := make(map[string]bool, len(pathURLs))
+
+	for _, p := range pathURLs {
+		if p.Path == "" {
+			errs = append(errs, fmt.Errorf("empty path (url %q)", p.URL))
+			continue
+		}
+		if !strings.HasPrefix(p.Path, "/") {
+			errs = append(errs, fmt.Errorf("path %q: must be absolute (start with /)", p.Path))
+		}
+		if seen[p.Path] {
+			errs = append(errs, fmt.Errorf("path %q: duplicate entry", p.Path))
+		}
+		seen[p.Path] = true
+
+		if p.URL.URL == nil {
+			errs = append(errs, fmt.Errorf("path %q: missing url", p.Path))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// buildHandler turns a parsed path list into an http.HandlerFunc,
+// merging any decode-time errors already collected in parseErr with
+// validatePathURLs's semantic checks into a single MultiError so a
+// caller sees every problem in the file at once instead of just the
+// first one encountered.
+func buildHandler(pathURLs []PathURL, parseErr error, fallback http.Handler) (http.HandlerFunc, error) {
+	var errs MultiError
+	switch e := parseErr.(type) {
+	case nil:
+	case MultiError:
+		errs = append(errs, e...)
+	default:
+		return nil, parseErr
+	}
+
+	if verr := validatePathURLs(pathURLs); verr != nil {
+		if me, ok := verr.(MultiError); ok {
+			errs = append(errs, me...)
+		} else {
+			errs = append(errs, verr)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return MapHandler(pathURLsToMap(pathURLs), fallback), nil
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// quotedValuePattern pulls the first "quoted" value out of an error
+// message, e.g. the raw url text parseRedirectURL quotes into its
+// error strings (`invalid url %q: ...`, `url %q must be absolute...`).
+var quotedValuePattern = regexp.MustCompile(`"([^"]*)"`)
+
+// wrapParseError converts a raw decode error from the YAML, JSON or
+// TOML libraries into a *ParseError carrying filename, line, column
+// and a caret-annotated snippet of src, so callers get a consistent,
+// actionable error regardless of config format.
+func wrapParseError(filename string, src []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var line, column int
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tomlErr toml.ParseError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column = offsetToLineColumn(src, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		line, column = offsetToLineColumn(src, typeErr.Offset)
+	case errors.As(err, &tomlErr):
+		// Compute column from the byte offset (as for the JSON branch
+		// above) rather than relying on Position.Col, whose presence
+		// has varied across BurntSushi/toml releases.
+		line, column = offsetToLineColumn(src, int64(tomlErr.Position.Start))
+	default:
+		if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+			fmt.Sscanf(m[1], "%d", &line)
+			column = 1
+		} else if m := quotedValuePattern.FindStringSubmatch(err.Error()); m != nil {
+			// RedirectURL's own validation errors (returned from
+			// UnmarshalJSON/UnmarshalText) carry no position of their
+			// own; locate the quoted raw value they name back in src
+			// instead.
+			if idx := bytes.Index(src, []byte(`"`+m[1]+`"`)); idx >= 0 {
+				line, column = offsetToLineColumn(src, int64(idx))
+			}
+		}
+	}
+
+	return &ParseError{
+		Filename: filename,
+		Line:     line,
+		Column:   column,
+		Snippet:  caretSnippet(src, line, column),
+		Err:      err,
+	}
+}
+
+// offsetToLineColumn converts a 0-indexed byte offset into src to a
+// 1-indexed (line, column) pair.
+func offsetToLineColumn(src []byte, offset int64) (line, column int) {
+	line = 1
+	lastNewline := -1
+	for i := 0; i < int(offset) && i < len(src); i++ {
+		if src[i] == '\n' {
+			line++
+			lastNewline = i
+		}
+	}
+	column = int(offset) - lastNewline
+	return line, column
+}
+
+// caretSnippet returns the 1-indexed line from src followed by a
+// second line carrying a "^" under column, for use in error messages.
+func caretSnippet(src []byte, line, column int) string {
+	lines := strings.Split(string(src), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	if column < 1 {
+		column = 1
+	}
+	return lines[line-1] + "\n" + strings.Repeat(" ", column-1) + "^"
+}
@@ -0,0 +1,104 @@
+package urlshort
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestYAMLHandlerReportsEveryProblemInOneRun(t *testing.T) {
+	yml := []byte(`
+- path: /bad
+  url: not-a-url
+- path: /dup
+  url: https://example.com/a
+- path: /dup
+  url: https://example.com/b
+`)
+
+	_, err := YAMLHandler(yml, fallbackHandler())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("got error of type %T, want MultiError", err)
+	}
+	// One decode-time error for the bad url on /bad, one validation
+	// error for the duplicate /dup path - both should surface
+	// together instead of the bad url hiding the duplicate.
+	if len(me) != 2 {
+		t.Fatalf("got %d error(s): %v, want 2", len(me), me)
+	}
+}
+
+func TestJSONHandlerReportsEveryProblemInOneRun(t *testing.T) {
+	data := []byte(`[
+		{"path": "/bad", "url": "not-a-url"},
+		{"path": "/dup", "url": "https://example.com/a"},
+		{"path": "/dup", "url": "https://example.com/b"}
+	]`)
+
+	_, err := JSONHandler(data, fallbackHandler())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("got error of type %T, want MultiError", err)
+	}
+	if len(me) != 2 {
+		t.Fatalf("got %d error(s): %v, want 2", len(me), me)
+	}
+}
+
+func TestJSONHandlerReportsPositionForMalformedURL(t *testing.T) {
+	data := []byte(`[
+		{"path": "/a", "url": "https://example.com/a"},
+		{"path": "/b", "url": "not-a-url"},
+		{"path": "/c", "url": "https://example.com/c"}
+	]`)
+
+	_, err := JSONHandler(data, fallbackHandler())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	me, ok := err.(MultiError)
+	if !ok || len(me) != 1 {
+		t.Fatalf("got error %v, want a MultiError with 1 entry", err)
+	}
+
+	var pe *ParseError
+	if !errors.As(me[0], &pe) {
+		t.Fatalf("got error of type %T, want *ParseError", me[0])
+	}
+	if pe.Line == 0 || pe.Snippet == "" {
+		t.Fatalf("got Line=%d Snippet=%q, want a non-zero line and a snippet pointing at the bad url", pe.Line, pe.Snippet)
+	}
+}
+
+func TestParseErrorUnwrap(t *testing.T) {
+	cause := errors.New("boom")
+	pe := &ParseError{Line: 3, Err: cause}
+
+	if !errors.Is(pe, cause) {
+		t.Fatal("expected errors.Is to find the wrapped cause")
+	}
+}
+
+func TestWrapParseErrorJSONSyntaxError(t *testing.T) {
+	bad := []byte("{\n  \"path\": \n}")
+	_, err := parseJSON(bad)
+	if err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got error of type %T, want *ParseError", err)
+	}
+	if pe.Line == 0 {
+		t.Fatal("expected a non-zero line number for the syntax error")
+	}
+}
@@ -1,28 +1,44 @@
 package urlshort
 
 import (
-	yaml "gopkg.in/yaml.v2"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	toml "github.com/BurntSushi/toml"
 )
 
+// PathURL represents a single entry mapping a URI path to
+// the URL it should redirect to. It is the common shape shared
+// by the YAML, JSON and TOML config formats.
+type PathURL struct {
+	Path string      `yaml:"path" json:"path" toml:"path"`
+	URL  RedirectURL `yaml:"url" json:"url" toml:"url"`
+}
+
 // YAMLPathURL represent YAML file which
 // maps a URI Path to another valid URL
-type YAMLPathURL struct {
-	URL  string `yaml:"url"`
-	Path string `yaml:"path"`
-}
+//
+// Deprecated: use PathURL instead. Kept as an alias so existing
+// callers referencing YAMLPathURL keep compiling.
+type YAMLPathURL = PathURL
 
 // MapHandler will return an http.HandlerFunc (which also
 // implements http.Handler) that will attempt to map any
 // paths (keys in the map) to their corresponding URL (values
-// that each key in the map points to, in string format).
-// If the path is not provided in the map, then the fallback
-// http.Handler will be called instead.
-func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.HandlerFunc {
+// that each key in the map points to). Holding already-parsed
+// *url.URL values means there's no re-parsing on the redirect
+// hot path. If the path is not provided in the map, then the
+// fallback http.Handler will be called instead.
+func MapHandler(pathsToUrls map[string]*url.URL, fallback http.Handler) http.HandlerFunc {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
-		if url, ok := pathsToUrls[path]; ok {
-			http.Redirect(w, r, url, http.StatusFound)
+		if target, ok := pathsToUrls[path]; ok {
+			http.Redirect(w, r, target.String(), http.StatusFound)
 			return
 		}
 		fallback.ServeHTTP(w, r)
@@ -30,50 +46,188 @@ func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.Handl
 	return handler
 }
 
-// YAMLHandler will parse the provided YAML and then return
-// an http.HandlerFunc (which also implements http.Handler)
-// that will attempt to map any paths to their corresponding
-// URL. If the path is not provided in the YAML, then the
-// fallback http.Handler will be called instead.
+// JSONHandler parses the provided JSON and returns an
+// http.HandlerFunc that behaves just like YAMLHandler, but reads
+// its config from JSON instead of YAML.
+//
+// JSON is expected to be in the format:
+//
+//     [
+//       {"path": "/some-path", "url": "https://www.some-url.com/demo"}
+//     ]
 //
-// YAML is expected to be in the format:
+// See YAMLHandler for the equivalent YAML-backed handler.
+func JSONHandler(data []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathURLs, err := parseJSON(data)
+	return buildHandler(pathURLs, err, fallback)
+}
+
+// TOMLHandler parses the provided TOML and returns an
+// http.HandlerFunc that behaves just like YAMLHandler, but reads
+// its config from TOML instead of YAML.
 //
-//     - path: /some-path
-//       url: https://www.some-url.com/demo
+// TOML is expected to be in the format:
 //
-// The only errors that can be returned all related to having
-// invalid YAML data.
+//     [[path]]
+//     path = "/some-path"
+//     url = "https://www.some-url.com/demo"
 //
-// See MapHandler to create a similar http.HandlerFunc via
-// a mapping of paths to urls.
-func YAMLHandler(yml []byte, fallback http.Handler) (http.HandlerFunc, error) {
-	var handler func(http.ResponseWriter, *http.Request)
+// See YAMLHandler for the equivalent YAML-backed handler.
+func TOMLHandler(data []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathURLs, err := parseTOML(data)
+	return buildHandler(pathURLs, err, fallback)
+}
+
+// ParseHandler dispatches to YAMLHandler, JSONHandler or TOMLHandler
+// based on format, which must be one of "yaml" (or "yml"), "json" or
+// "toml" (case-insensitive). It returns an error if format is anything
+// else.
+func ParseHandler(data []byte, format string, fallback http.Handler) (http.HandlerFunc, error) {
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return YAMLHandler(data, fallback)
+	case "json":
+		return JSONHandler(data, fallback)
+	case "toml":
+		return TOMLHandler(data, fallback)
+	default:
+		return nil, fmt.Errorf("urlshort: unsupported config format %q", format)
+	}
+}
+
+// parseJSON take JSON file content in []byte (array) and map the content
+// into a PathURL struct above.
+//
+// Each element is decoded individually via json.RawMessage rather than
+// decoding the whole array in one json.Unmarshal call: encoding/json
+// aborts an array decode at the first element whose UnmarshalJSON
+// fails, which would otherwise hide every problem after the first bad
+// url in the file. A MultiError collecting every element's decode
+// error is returned alongside whatever entries did decode.
+func parseJSON(data []byte) ([]PathURL, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, wrapParseError("", data, err)
+	}
+
+	var pathURLs []PathURL
+	var errs MultiError
+	for _, elem := range raw {
+		var pathURL PathURL
+		if err := json.Unmarshal(elem, &pathURL); err != nil {
+			errs = append(errs, wrapParseError("", elem, err))
+			continue
+		}
+		pathURLs = append(pathURLs, pathURL)
+	}
 
-	yamlPathURLs, err := parseYAML(yml)
+	if len(errs) > 0 {
+		return pathURLs, errs
+	}
+	return pathURLs, nil
+}
+
+// parseTOML take TOML file content in []byte (array) and map the content
+// into a PathURL struct above.
+//
+// The path array is first decoded as []toml.Primitive, deferring each
+// element's decode so a bad entry doesn't abort the whole document (the
+// same reasoning as parseJSON's use of json.RawMessage above).
+func parseTOML(data []byte) ([]PathURL, error) {
+	var doc struct {
+		Path []toml.Primitive `toml:"path"`
+	}
+	meta, err := toml.Decode(string(data), &doc)
 	if err != nil {
-		return handler, err
+		return nil, wrapParseError("", data, err)
 	}
 
-	pathsToUrls := makeYAMLToMap(yamlPathURLs)
-	handler = MapHandler(pathsToUrls, fallback)
-	return handler, err
+	offsets := tomlPathOffsets(data)
+
+	var pathURLs []PathURL
+	var errs MultiError
+	for i, prim := range doc.Path {
+		var pathURL PathURL
+		if err := meta.PrimitiveDecode(prim, &pathURL); err != nil {
+			errs = append(errs, wrapTOMLEntryError(data, offsets, i, err))
+			continue
+		}
+		pathURLs = append(pathURLs, pathURL)
+	}
+
+	if len(errs) > 0 {
+		return pathURLs, errs
+	}
+	return pathURLs, nil
 }
 
-// parseYAML take YAML file content in []byte (array) and map the content
-// into a YAMLPathURL struct above
-func parseYAML(yml []byte) ([]YAMLPathURL, error) {
-	var yamlPathURLs []YAMLPathURL
-	err := yaml.Unmarshal(yml, &yamlPathURLs)
+// tomlPathHeaderPattern matches a "[[path]]" array-of-tables header on
+// its own line, used by tomlPathOffsets to find where each entry
+// starts in the source.
+var tomlPathHeaderPattern = regexp.MustCompile(`(?m)^[ \t]*\[\[path\]\][ \t]*$`)
 
-	return yamlPathURLs, err
+// tomlPathOffsets returns the byte offset of each "[[path]]" header in
+// data, in file order, giving wrapTOMLEntryError a byte range to
+// search within for entry i.
+func tomlPathOffsets(data []byte) []int {
+	locs := tomlPathHeaderPattern.FindAllIndex(data, -1)
+	offsets := make([]int, len(locs))
+	for i, loc := range locs {
+		offsets[i] = loc[0]
+	}
+	return offsets
+}
+
+// wrapTOMLEntryError reports a decode error for path entry index as a
+// *ParseError pointing at that entry's own text, rather than trusting
+// err's toml.ParseError.Position: BurntSushi/toml's MetaData.PrimitiveDecode
+// looks up position by dotted key (e.g. "path.url"), and every element
+// of a "[[path]]" array of tables shares that same key, so Position
+// ends up reflecting whichever entry was parsed last rather than the
+// one actually being decoded. Instead, this confines the search for
+// the offending key to the byte range between this entry's "[[path]]"
+// header and the next, falling back to the header's own position if
+// the key can't be found there.
+func wrapTOMLEntryError(data []byte, offsets []int, index int, err error) error {
+	if index >= len(offsets) {
+		return wrapParseError("", data, err)
+	}
+	start := offsets[index]
+	end := len(data)
+	if index+1 < len(offsets) {
+		end = offsets[index+1]
+	}
+
+	var tomlErr toml.ParseError
+	offset := start
+	if errors.As(err, &tomlErr) {
+		key := tomlErr.LastKey
+		if i := strings.LastIndexByte(key, '.'); i >= 0 {
+			key = key[i+1:]
+		}
+		if key != "" {
+			keyPattern := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(key) + `\s*=`)
+			if loc := keyPattern.FindIndex(data[start:end]); loc != nil {
+				offset = start + loc[0]
+			}
+		}
+	}
+
+	line, column := offsetToLineColumn(data, int64(offset))
+	return &ParseError{
+		Line:    line,
+		Column:  column,
+		Snippet: caretSnippet(data, line, column),
+		Err:     err,
+	}
 }
 
-// makeYAMLToMap convert array of struct, []YAMLPathURL, into
+// pathURLsToMap convert array of struct, []PathURL, into
 // native map of Path to URLs
-func makeYAMLToMap(yamlPathURLs []YAMLPathURL) map[string]string {
-	var pathsToUrls = make(map[string]string)
-	for _, yamlPathURL := range yamlPathURLs {
-		pathsToUrls[yamlPathURL.Path] = yamlPathURL.URL
+func pathURLsToMap(pathURLs []PathURL) map[string]*url.URL {
+	var pathsToUrls = make(map[string]*url.URL, len(pathURLs))
+	for _, pathURL := range pathURLs {
+		pathsToUrls[pathURL.Path] = pathURL.URL.URL
 	}
 	return pathsToUrls
 }
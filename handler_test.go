@@ -0,0 +1,111 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func fallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+func TestJSONHandler(t *testing.T) {
+	data := []byte(`[{"path": "/urlshort", "url": "https://github.com/gophercises/urlshort"}]`)
+
+	handler, err := JSONHandler(data, fallbackHandler())
+	if err != nil {
+		t.Fatalf("JSONHandler returned error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/urlshort", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusFound)
+	}
+	if got := rec.Header().Get("Location"); got != "https://github.com/gophercises/urlshort" {
+		t.Fatalf("got Location %q", got)
+	}
+}
+
+func TestTOMLHandler(t *testing.T) {
+	data := []byte(`
+[[path]]
+path = "/urlshort"
+url = "https://github.com/gophercises/urlshort"
+`)
+
+	handler, err := TOMLHandler(data, fallbackHandler())
+	if err != nil {
+		t.Fatalf("TOMLHandler returned error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/urlshort", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusFound)
+	}
+	if got := rec.Header().Get("Location"); got != "https://github.com/gophercises/urlshort" {
+		t.Fatalf("got Location %q", got)
+	}
+}
+
+func TestTOMLHandlerReportsTheFailingEntrysOwnLine(t *testing.T) {
+	data := []byte(`
+[[path]]
+path = "/a"
+url = "https://example.com/a"
+
+[[path]]
+path = "/b"
+url = 123
+
+[[path]]
+path = "/c"
+url = "https://example.com/c"
+`)
+
+	_, err := TOMLHandler(data, fallbackHandler())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	me, ok := err.(MultiError)
+	if !ok || len(me) != 1 {
+		t.Fatalf("got error %v, want a MultiError with 1 entry", err)
+	}
+
+	var pe *ParseError
+	if !errors.As(me[0], &pe) {
+		t.Fatalf("got error of type %T, want *ParseError", me[0])
+	}
+	// The bad "url = 123" is on line 8; MetaData.PrimitiveDecode's own
+	// Position tracks the array-of-tables key generically and would
+	// misreport line 12 (the last "[[path]]" entry's url line) here.
+	if pe.Line != 8 {
+		t.Fatalf("got line %d, want 8 (got wrong entry's position)", pe.Line)
+	}
+}
+
+func TestParseHandlerIsCaseInsensitive(t *testing.T) {
+	data := []byte(`[{"path": "/urlshort", "url": "https://github.com/gophercises/urlshort"}]`)
+
+	for _, format := range []string{"json", "JSON", "Json"} {
+		if _, err := ParseHandler(data, format, fallbackHandler()); err != nil {
+			t.Errorf("ParseHandler(format=%q) returned error: %v", format, err)
+		}
+	}
+}
+
+func TestParseHandlerUnsupportedFormat(t *testing.T) {
+	if _, err := ParseHandler(nil, "xml", fallbackHandler()); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
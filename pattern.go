@@ -0,0 +1,171 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// patternSegment is one "/"-delimited piece of a compiled pattern:
+// either a literal that must match verbatim, a named parameter
+// (":id") that captures a single segment, or a trailing wildcard
+// ("*rest") that captures the remainder of the path.
+type patternSegment struct {
+	literal  string
+	param    string
+	wildcard string
+}
+
+// compiledPattern is a pattern path, broken into segments, alongside
+// the URL template its captures are substituted into.
+type compiledPattern struct {
+	segments         []patternSegment
+	literalPrefixLen int
+	target           string
+}
+
+var templateParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// PatternHandler returns an http.HandlerFunc that matches a request
+// path against patterns containing named parameters (e.g. "/u/:id")
+// and a trailing wildcard (e.g. "/docs/*rest"), substituting captured
+// values into the target URL template (e.g. "https://example.com/users/{id}")
+// before redirecting.
+//
+// Patterns with no ":" or "*" segment are matched via a plain map
+// lookup first, so the common exact-match case stays O(1); the
+// compiled pattern list is only consulted on a miss. When more than
+// one pattern could match the same path, the one with the longest
+// literal prefix wins.
+//
+// See MapHandler for the plain exact-match equivalent.
+func PatternHandler(patterns []PathURL, fallback http.Handler) (http.HandlerFunc, error) {
+	exact := make(map[string]string)
+	var compiled []compiledPattern
+
+	for _, p := range patterns {
+		if p.URL.URL == nil {
+			return nil, fmt.Errorf("urlshort: pattern %q: missing url", p.Path)
+		}
+
+		// Use the raw, as-written template rather than p.URL.String():
+		// round-tripping through url.Parse/String percent-encodes "{"
+		// and "}", which would stop templateParam from ever matching.
+		target := p.URL.Raw
+		if !strings.ContainsAny(p.Path, ":*") {
+			exact[p.Path] = target
+			continue
+		}
+		cp, err := compilePattern(p.Path, target)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, cp)
+	}
+
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return compiled[i].literalPrefixLen > compiled[j].literalPrefixLen
+	})
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if target, ok := exact[path]; ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		for _, cp := range compiled {
+			if target, ok := cp.match(path); ok {
+				http.Redirect(w, r, target, http.StatusFound)
+				return
+			}
+		}
+		fallback.ServeHTTP(w, r)
+	}
+	return handler, nil
+}
+
+// compilePattern breaks pattern into its "/"-delimited segments and
+// pairs the result with target, the URL template its captures will
+// be substituted into.
+func compilePattern(pattern, target string) (compiledPattern, error) {
+	parts := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	segments := make([]patternSegment, 0, len(parts))
+
+	prefixLen := 0
+	inPrefix := true
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, ":"):
+			if part == ":" {
+				return compiledPattern{}, fmt.Errorf("urlshort: pattern %q: empty named parameter", pattern)
+			}
+			segments = append(segments, patternSegment{param: part[1:]})
+			inPrefix = false
+		case strings.HasPrefix(part, "*"):
+			if i != len(parts)-1 {
+				return compiledPattern{}, fmt.Errorf("urlshort: pattern %q: wildcard segment must be last", pattern)
+			}
+			if part == "*" {
+				return compiledPattern{}, fmt.Errorf("urlshort: pattern %q: empty wildcard name", pattern)
+			}
+			segments = append(segments, patternSegment{wildcard: part[1:]})
+			inPrefix = false
+		default:
+			segments = append(segments, patternSegment{literal: part})
+			if inPrefix {
+				prefixLen += len(part) + 1
+			}
+		}
+	}
+
+	return compiledPattern{segments: segments, literalPrefixLen: prefixLen, target: target}, nil
+}
+
+// match reports whether path satisfies cp, returning the target URL
+// with any captured parameters substituted in.
+func (cp compiledPattern) match(path string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	params := make(map[string]string, len(cp.segments))
+
+	for i, seg := range cp.segments {
+		if seg.wildcard != "" {
+			if i > len(parts) {
+				return "", false
+			}
+			params[seg.wildcard] = strings.Join(parts[i:], "/")
+			return substituteTemplate(cp.target, params), true
+		}
+		if i >= len(parts) {
+			return "", false
+		}
+		if seg.param != "" {
+			if parts[i] == "" {
+				return "", false
+			}
+			params[seg.param] = parts[i]
+			continue
+		}
+		if parts[i] != seg.literal {
+			return "", false
+		}
+	}
+
+	if len(parts) != len(cp.segments) {
+		return "", false
+	}
+	return substituteTemplate(cp.target, params), true
+}
+
+// substituteTemplate replaces every "{name}" placeholder in tmpl with
+// its captured value from params, leaving unknown placeholders as-is.
+func substituteTemplate(tmpl string, params map[string]string) string {
+	return templateParam.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := m[1 : len(m)-1]
+		if v, ok := params[name]; ok {
+			return v
+		}
+		return m
+	})
+}
@@ -0,0 +1,86 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustPathURL(t *testing.T, path, rawURL string) PathURL {
+	t.Helper()
+	u, err := parseRedirectURL(rawURL)
+	if err != nil {
+		t.Fatalf("parseRedirectURL(%q) returned error: %v", rawURL, err)
+	}
+	return PathURL{Path: path, URL: RedirectURL{Raw: rawURL, URL: u}}
+}
+
+func TestPatternHandlerSubstitutesCapturedParam(t *testing.T) {
+	patterns := []PathURL{
+		mustPathURL(t, "/u/:id", "https://example.com/users/{id}"),
+	}
+
+	handler, err := PatternHandler(patterns, fallbackHandler())
+	if err != nil {
+		t.Fatalf("PatternHandler returned error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/u/42", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusFound)
+	}
+	want := "https://example.com/users/42"
+	if got := rec.Header().Get("Location"); got != want {
+		t.Fatalf("Location = %q, want %q (the {id} placeholder must not be percent-encoded)", got, want)
+	}
+}
+
+func TestPatternHandlerWildcard(t *testing.T) {
+	patterns := []PathURL{
+		mustPathURL(t, "/docs/*rest", "https://docs.example.com/{rest}"),
+	}
+
+	handler, err := PatternHandler(patterns, fallbackHandler())
+	if err != nil {
+		t.Fatalf("PatternHandler returned error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/docs/a/b/c", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	want := "https://docs.example.com/a/b/c"
+	if got := rec.Header().Get("Location"); got != want {
+		t.Fatalf("Location = %q, want %q", got, want)
+	}
+}
+
+func TestPatternHandlerExactMatchTakesHashmapFastPath(t *testing.T) {
+	patterns := []PathURL{
+		mustPathURL(t, "/about", "https://example.com/about-us"),
+	}
+
+	handler, err := PatternHandler(patterns, fallbackHandler())
+	if err != nil {
+		t.Fatalf("PatternHandler returned error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/about", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Location"); got != "https://example.com/about-us" {
+		t.Fatalf("Location = %q", got)
+	}
+}
+
+func TestPatternHandlerRejectsMissingURL(t *testing.T) {
+	patterns := []PathURL{{Path: "/u/:id"}}
+
+	if _, err := PatternHandler(patterns, fallbackHandler()); err == nil {
+		t.Fatal("expected PatternHandler to reject a pattern missing its url")
+	}
+}
@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// RedirectURL wraps *url.URL so every config format validates a
+// redirect target the same way at load time: parsed, confirmed
+// absolute, and restricted to http/https. This turns a malformed URL
+// into a load-time error instead of a broken redirect discovered by a
+// user hitting the endpoint.
+//
+// Raw keeps the exact string as written in the config, since
+// round-tripping it through url.Parse/String can percent-encode
+// characters (e.g. "{" and "}" in a PatternHandler template) that
+// callers relying on the original text need untouched.
+type RedirectURL struct {
+	Raw string
+	*url.URL
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, decoding the scalar node
+// as a string and validating it via parseRedirectURL.
+func (r *RedirectURL) UnmarshalYAML(node *yaml.Node) error {
+	var raw string
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	u, err := parseRedirectURL(raw)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	r.Raw = raw
+	r.URL = u
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, applying the same
+// validation as UnmarshalYAML.
+func (r *RedirectURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return r.UnmarshalText([]byte(raw))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, which is how
+// BurntSushi/toml decodes a TOML string into a custom type. JSON
+// decoding funnels through this too, and UnmarshalYAML calls the same
+// parseRedirectURL, so all three formats enforce the exact same rule.
+func (r *RedirectURL) UnmarshalText(text []byte) error {
+	u, err := parseRedirectURL(string(text))
+	if err != nil {
+		return err
+	}
+	r.Raw = string(text)
+	r.URL = u
+	return nil
+}
+
+// parseRedirectURL parses raw and ensures it's an absolute http(s)
+// URL, returning a descriptive error otherwise.
+func parseRedirectURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", raw, err)
+	}
+	if !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("url %q must be absolute with an http or https scheme", raw)
+	}
+	return u, nil
+}
@@ -0,0 +1,63 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidatePathURLsRejectsMissingURL(t *testing.T) {
+	pathURLs := []PathURL{{Path: "/foo"}}
+
+	err := validatePathURLs(pathURLs)
+	if err == nil {
+		t.Fatal("expected an error for a path entry with no url")
+	}
+}
+
+func TestYAMLHandlerRejectsMissingURL(t *testing.T) {
+	yml := []byte(`
+- path: /foo
+`)
+
+	if _, err := YAMLHandler(yml, fallbackHandler()); err == nil {
+		t.Fatal("expected YAMLHandler to reject an entry missing its url")
+	}
+}
+
+func TestJSONHandlerMissingURLDoesNotPanic(t *testing.T) {
+	data := []byte(`[{"path": "/foo"}]`)
+
+	handler, err := JSONHandler(data, fallbackHandler())
+	if err == nil {
+		t.Fatal("expected JSONHandler to reject an entry missing its url")
+	}
+	if handler != nil {
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func TestParseRedirectURLRejectsNonHTTPScheme(t *testing.T) {
+	if _, err := parseRedirectURL("ftp://example.com/foo"); err == nil {
+		t.Fatal("expected a non-http(s) scheme to be rejected")
+	}
+	if _, err := parseRedirectURL("/relative/path"); err == nil {
+		t.Fatal("expected a relative url to be rejected")
+	}
+}
+
+func TestRedirectURLPreservesRawText(t *testing.T) {
+	data := []byte(`[{"path": "/u/:id", "url": "https://example.com/users/{id}"}]`)
+
+	pathURLs, err := parseJSON(data)
+	if err != nil {
+		t.Fatalf("parseJSON returned error: %v", err)
+	}
+	if len(pathURLs) != 1 {
+		t.Fatalf("got %d entries, want 1", len(pathURLs))
+	}
+	if got := pathURLs[0].URL.Raw; got != "https://example.com/users/{id}" {
+		t.Fatalf("RedirectURL.Raw = %q, want the literal template", got)
+	}
+}
@@ -0,0 +1,277 @@
+package urlshort
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// includeTag is the custom YAML tag used to splice the contents of
+// another file's path list into the current one, e.g.:
+//
+//     - !include common.yaml
+//     - path: /some-path
+//       url: https://www.some-url.com/demo
+const includeTag = "!include"
+
+// YAMLHandler will parse the provided YAML and then return
+// an http.HandlerFunc (which also implements http.Handler)
+// that will attempt to map any paths to their corresponding
+// URL. If the path is not provided in the YAML, then the
+// fallback http.Handler will be called instead.
+//
+// YAML is expected to be in the format:
+//
+//     - path: /some-path
+//       url: https://www.some-url.com/demo
+//
+// Anchors and aliases are resolved as part of standard YAML
+// unmarshaling, so an anchor placed over a single entry can be
+// aliased in place of another single entry. To reuse a whole batch of
+// entries, anchor them under a key of their own and alias that key's
+// sequence as one item of a "paths" list:
+//
+//     common: &docs
+//       - path: /docs/a
+//         url: https://example.com/a
+//       - path: /docs/b
+//         url: https://example.com/b
+//
+//     paths:
+//       - *docs
+//       - path: /other
+//         url: https://example.com/other
+//
+// A list item that is, or resolves through an alias to, a nested
+// sequence is spliced in place rather than decoded as a single entry,
+// which is what lets the whole "common" batch above stand in for one
+// item. To share entries across files instead of within one, use
+// YAMLHandlerFS.
+//
+// The only errors that can be returned all related to having
+// invalid YAML data.
+//
+// See MapHandler to create a similar http.HandlerFunc via
+// a mapping of paths to urls.
+func YAMLHandler(yml []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathURLs, err := parseYAML(yml)
+	return buildHandler(pathURLs, err, fallback)
+}
+
+// YAMLHandlerFS is like YAMLHandler, but reads entrypoint from fsys and
+// resolves any `!include other.yaml` entries it encounters along the
+// way, splicing the included file's entries in place. Included paths
+// are resolved relative to fsys's root, not to entrypoint's directory.
+//
+// A chain of includes that refers back to a file already being
+// resolved is reported as an *IncludeCycleError rather than recursing
+// forever. Any parse failure is reported as a "file:line: ..." wrapped
+// error so the offending file is obvious.
+func YAMLHandlerFS(fsys fs.FS, entrypoint string, fallback http.Handler) (http.HandlerFunc, error) {
+	pathURLs, err := parseYAMLFS(fsys, entrypoint, map[string]bool{})
+	return buildHandler(pathURLs, err, fallback)
+}
+
+// IncludeCycleError is returned by YAMLHandlerFS when a chain of
+// `!include` entries refers back to a file that is already in the
+// process of being resolved.
+type IncludeCycleError struct {
+	File string
+}
+
+func (e *IncludeCycleError) Error() string {
+	return fmt.Sprintf("urlshort: include cycle detected at %s", e.File)
+}
+
+// AliasCycleError is returned when a YAML anchor's own sequence
+// (directly or through further aliases) refers back to itself, which
+// would otherwise send decodeSequenceItem into unbounded recursion.
+type AliasCycleError struct {
+	Anchor string
+}
+
+func (e *AliasCycleError) Error() string {
+	return fmt.Sprintf("urlshort: alias cycle detected at anchor %q", e.Anchor)
+}
+
+// parseYAML take YAML file content in []byte (array) and map the content
+// into a PathURL struct above.
+//
+// Entries are decoded one at a time off a parsed yaml.Node rather than
+// via a single yaml.Unmarshal(yml, &pathURLs) call, so that one bad
+// entry doesn't abort the whole document and hide every other
+// problem in the file; see parseYAMLFS, which does the same.
+func parseYAML(yml []byte) ([]PathURL, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yml, &doc); err != nil {
+		return nil, wrapParseError("", yml, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	seq := pathSequence(doc.Content[0])
+	if seq == nil {
+		return nil, nil
+	}
+
+	var pathURLs []PathURL
+	var errs MultiError
+	for _, item := range seq.Content {
+		decoded, err := decodeSequenceItem(item, "", yml)
+		if me, ok := err.(MultiError); ok {
+			errs = append(errs, me...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+		pathURLs = append(pathURLs, decoded...)
+	}
+
+	if len(errs) > 0 {
+		return pathURLs, errs
+	}
+	return pathURLs, nil
+}
+
+// resolveAlias follows n.Alias until it reaches a concrete, non-alias
+// node. It returns n unchanged if n isn't an alias.
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// inflight tracks the sequence nodes currently being spliced by
+// decodeSequenceItem, so a self-referential anchor (one whose own
+// sequence contains, directly or via further aliases, an alias back to
+// itself) is reported as an *AliasCycleError instead of recursing
+// until the goroutine's stack overflows.
+type inflight map[*yaml.Node]bool
+
+// pathSequence returns the sequence of path entries within root: root
+// itself when root is already a sequence (the common top-level-array
+// format), or the value of a "paths" key when root is a mapping. The
+// mapping form exists so a batch of entries can be anchored under a
+// key of its own (e.g. "common") purely to be aliased from "paths",
+// without that key's value being decoded as path entries itself.
+func pathSequence(root *yaml.Node) *yaml.Node {
+	switch root.Kind {
+	case yaml.SequenceNode:
+		return root
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "paths" {
+				return root.Content[i+1]
+			}
+		}
+	}
+	return nil
+}
+
+// decodeSequenceItem decodes one item of a path sequence into zero or
+// more PathURL entries. A plain mapping decodes to exactly one entry.
+// An item that resolves (possibly through an alias) to another
+// sequence is flattened instead, which is what lets an anchor placed
+// over a batch of entries be reused elsewhere via a single alias.
+func decodeSequenceItem(item *yaml.Node, filename string, src []byte) ([]PathURL, error) {
+	return decodeSequenceItemInflight(item, filename, src, inflight{})
+}
+
+func decodeSequenceItemInflight(item *yaml.Node, filename string, src []byte, seen inflight) ([]PathURL, error) {
+	if resolved := resolveAlias(item); resolved != nil && resolved.Kind == yaml.SequenceNode {
+		if seen[resolved] {
+			return nil, &AliasCycleError{Anchor: resolved.Anchor}
+		}
+		seen[resolved] = true
+		defer delete(seen, resolved)
+
+		var pathURLs []PathURL
+		var errs MultiError
+		for _, sub := range resolved.Content {
+			decoded, err := decodeSequenceItemInflight(sub, filename, src, seen)
+			if me, ok := err.(MultiError); ok {
+				errs = append(errs, me...)
+			} else if err != nil {
+				errs = append(errs, err)
+			}
+			pathURLs = append(pathURLs, decoded...)
+		}
+		if len(errs) > 0 {
+			return pathURLs, errs
+		}
+		return pathURLs, nil
+	}
+
+	var pathURL PathURL
+	if err := item.Decode(&pathURL); err != nil {
+		return nil, &ParseError{
+			Filename: filename,
+			Line:     item.Line,
+			Column:   item.Column,
+			Snippet:  caretSnippet(src, item.Line, item.Column),
+			Err:      err,
+		}
+	}
+	return []PathURL{pathURL}, nil
+}
+
+// parseYAMLFS resolves filename within fsys, recursively splicing in
+// any `!include` entries, and returns the fully resolved path list.
+// visiting tracks the files currently being resolved so that a cycle
+// can be reported instead of recursing forever.
+func parseYAMLFS(fsys fs.FS, filename string, visiting map[string]bool) ([]PathURL, error) {
+	if visiting[filename] {
+		return nil, &IncludeCycleError{File: filename}
+	}
+	visiting[filename] = true
+	defer delete(visiting, filename)
+
+	data, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return nil, fmt.Errorf("urlshort: reading %s: %w", filename, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, wrapParseError(filename, data, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	seq := pathSequence(doc.Content[0])
+	if seq == nil {
+		return nil, nil
+	}
+
+	var pathURLs []PathURL
+	var errs MultiError
+	for _, item := range seq.Content {
+		if item.Tag == includeTag {
+			included, err := parseYAMLFS(fsys, item.Value, visiting)
+			if me, ok := err.(MultiError); ok {
+				errs = append(errs, me...)
+			} else if err != nil {
+				return nil, err
+			}
+			pathURLs = append(pathURLs, included...)
+			continue
+		}
+
+		decoded, err := decodeSequenceItem(item, filename, data)
+		if me, ok := err.(MultiError); ok {
+			errs = append(errs, me...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+		pathURLs = append(pathURLs, decoded...)
+	}
+
+	if len(errs) > 0 {
+		return pathURLs, errs
+	}
+	return pathURLs, nil
+}
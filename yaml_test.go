@@ -0,0 +1,167 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestYAMLHandlerSingleEntryAlias(t *testing.T) {
+	yml := []byte(`
+- path: /about
+  url: &shared https://example.com/about-us
+- path: /info
+  url: *shared
+`)
+
+	handler, err := YAMLHandler(yml, fallbackHandler())
+	if err != nil {
+		t.Fatalf("YAMLHandler returned error: %v", err)
+	}
+
+	for _, path := range []string{"/about", "/info"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Location"); got != "https://example.com/about-us" {
+			t.Fatalf("path %s: Location = %q", path, got)
+		}
+	}
+}
+
+func TestYAMLHandlerBatchAliasSplicing(t *testing.T) {
+	yml := []byte(`
+common: &docs
+  - path: /docs/a
+    url: https://example.com/a
+  - path: /docs/b
+    url: https://example.com/b
+
+paths:
+  - *docs
+  - path: /other
+    url: https://example.com/other
+`)
+
+	handler, err := YAMLHandler(yml, fallbackHandler())
+	if err != nil {
+		t.Fatalf("YAMLHandler returned error: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"/docs/a": "https://example.com/a",
+		"/docs/b": "https://example.com/b",
+		"/other":  "https://example.com/other",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Location"); got != want {
+			t.Errorf("path %s: Location = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestYAMLHandlerFSResolvesInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"common.yaml": &fstest.MapFile{Data: []byte(`
+- path: /docs
+  url: https://example.com/docs
+`)},
+		"main.yaml": &fstest.MapFile{Data: []byte(`
+- !include common.yaml
+- path: /other
+  url: https://example.com/other
+`)},
+	}
+
+	handler, err := YAMLHandlerFS(fsys, "main.yaml", fallbackHandler())
+	if err != nil {
+		t.Fatalf("YAMLHandlerFS returned error: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"/docs":  "https://example.com/docs",
+		"/other": "https://example.com/other",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Location"); got != want {
+			t.Errorf("path %s: Location = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestYAMLHandlerDetectsSelfReferentialAlias(t *testing.T) {
+	yml := []byte(`
+common: &docs
+  - *docs
+paths:
+  - *docs
+`)
+
+	_, err := YAMLHandler(yml, fallbackHandler())
+	if err == nil {
+		t.Fatal("expected an alias cycle error instead of recursing forever")
+	}
+	var cycleErr *AliasCycleError
+	if !asAliasCycleError(err, &cycleErr) {
+		t.Fatalf("got error of type %T, want *AliasCycleError (or a MultiError containing one)", err)
+	}
+}
+
+// asAliasCycleError reports whether err is, or contains (via
+// MultiError), an *AliasCycleError, storing it into *target.
+func asAliasCycleError(err error, target **AliasCycleError) bool {
+	if ce, ok := err.(*AliasCycleError); ok {
+		*target = ce
+		return true
+	}
+	if me, ok := err.(MultiError); ok {
+		for _, e := range me {
+			if asAliasCycleError(e, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestYAMLHandlerFSDetectsIncludeCycle(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml": &fstest.MapFile{Data: []byte(`
+- !include b.yaml
+`)},
+		"b.yaml": &fstest.MapFile{Data: []byte(`
+- !include a.yaml
+`)},
+	}
+
+	_, err := YAMLHandlerFS(fsys, "a.yaml", fallbackHandler())
+	if err == nil {
+		t.Fatal("expected an include cycle error")
+	}
+	var cycleErr *IncludeCycleError
+	if !asIncludeCycleError(err, &cycleErr) {
+		t.Fatalf("got error of type %T, want *IncludeCycleError (or a MultiError containing one)", err)
+	}
+}
+
+// asIncludeCycleError reports whether err is, or contains (via
+// MultiError), an *IncludeCycleError, storing it into *target.
+func asIncludeCycleError(err error, target **IncludeCycleError) bool {
+	if ce, ok := err.(*IncludeCycleError); ok {
+		*target = ce
+		return true
+	}
+	if me, ok := err.(MultiError); ok {
+		for _, e := range me {
+			if asIncludeCycleError(e, target) {
+				return true
+			}
+		}
+	}
+	return false
+}